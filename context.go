@@ -0,0 +1,84 @@
+package rslog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+)
+
+type contextKey int
+
+const loggerContextKey contextKey = iota
+
+// ContextExtractor pulls fields out of a context.Context to attach to every
+// log line produced through Ctx, e.g. a request ID or trace ID.
+type ContextExtractor func(context.Context) []interface{}
+
+var (
+	extractorsMu sync.Mutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor adds fn to the set of extractors consulted by
+// Ctx. Typical uses pull a request ID, trace ID or tenant ID out of the
+// context so every log line written through Ctx includes them
+// automatically, without threading a logger through every function.
+func RegisterContextExtractor(fn ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, fn)
+}
+
+// WithLogger returns a copy of ctx carrying l, retrievable with FromContext
+// or Ctx. If ctx already carries this exact logger, ctx is returned
+// unchanged so nested WithLogger calls don't allocate needlessly.
+func WithLogger(ctx context.Context, l log15.Logger) context.Context {
+	if existing, ok := ctx.Value(loggerContextKey).(log15.Logger); ok && sameLogger(existing, l) {
+		return ctx
+	}
+	return context.WithValue(ctx, loggerContextKey, l)
+}
+
+// sameLogger reports whether a and b are the same logger. log15.Logger is an
+// interface, and some implementations (including value-type ones holding a
+// slice or map) are not comparable with ==, which would otherwise panic;
+// treat those as never equal rather than risk it.
+func sameLogger(a, b log15.Logger) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// FromContext returns the logger stored in ctx by WithLogger, or log15.Root()
+// if none was stored.
+func FromContext(ctx context.Context) log15.Logger {
+	if l, ok := ctx.Value(loggerContextKey).(log15.Logger); ok {
+		return l
+	}
+	return log15.Root()
+}
+
+// Ctx returns the logger stored in ctx (see FromContext) with every
+// registered ContextExtractor's fields applied, so callers can just do
+// rslog.Ctx(ctx).Info(...) and automatically get request-scoped fields that
+// middleware attached earlier.
+func Ctx(ctx context.Context) log15.Logger {
+	l := FromContext(ctx)
+
+	extractorsMu.Lock()
+	fns := extractors
+	extractorsMu.Unlock()
+
+	var fields []interface{}
+	for _, fn := range fns {
+		fields = append(fields, fn(ctx)...)
+	}
+	if len(fields) == 0 {
+		return l
+	}
+	return l.New(fields...)
+}
@@ -0,0 +1,74 @@
+package rslog
+
+import (
+	"context"
+
+	"github.com/inconshreveable/log15"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WithLogger/FromContext", func() {
+	It("round-trips the logger stored in the context", func() {
+		l := log15.New("k", "v")
+		ctx := WithLogger(context.Background(), l)
+
+		Ω(FromContext(ctx)).Should(Equal(l))
+	})
+
+	It("falls back to log15.Root when no logger was stored", func() {
+		Ω(FromContext(context.Background())).Should(Equal(log15.Root()))
+	})
+
+	It("does not panic when the same uncomparable logger is stored twice", func() {
+		l := uncomparableLogger{tags: []string{"a"}}
+
+		Ω(func() {
+			ctx := WithLogger(context.Background(), l)
+			WithLogger(ctx, l)
+		}).ShouldNot(Panic())
+	})
+})
+
+var _ = Describe("Ctx", func() {
+	It("applies every registered ContextExtractor's fields on top of the stored logger", func() {
+		type reqIDKey struct{}
+		ctx := context.WithValue(context.Background(), reqIDKey{}, "req-123")
+
+		RegisterContextExtractor(func(ctx context.Context) []interface{} {
+			id, _ := ctx.Value(reqIDKey{}).(string)
+			if id == "" {
+				return nil
+			}
+			return []interface{}{"req_id", id}
+		})
+
+		rec := &recordingHandler{}
+		stored := log15.New()
+		stored.SetHandler(rec)
+		ctx = WithLogger(ctx, stored)
+
+		l := Ctx(ctx)
+		Ω(l).ShouldNot(BeNil())
+
+		l.Info("hi")
+		Ω(rec.records).Should(HaveLen(1))
+		Ω(rec.records[0].Ctx).Should(Equal([]interface{}{"req_id", "req-123"}))
+	})
+})
+
+// uncomparableLogger is a log15.Logger implementation whose concrete type
+// holds a slice, making it uncomparable with == (it would panic if compared
+// directly rather than through sameLogger).
+type uncomparableLogger struct {
+	tags []string
+}
+
+func (l uncomparableLogger) New(ctx ...interface{}) log15.Logger  { return l }
+func (l uncomparableLogger) GetHandler() log15.Handler            { return nil }
+func (l uncomparableLogger) SetHandler(h log15.Handler)           {}
+func (l uncomparableLogger) Debug(msg string, ctx ...interface{}) {}
+func (l uncomparableLogger) Info(msg string, ctx ...interface{})  {}
+func (l uncomparableLogger) Warn(msg string, ctx ...interface{})  {}
+func (l uncomparableLogger) Error(msg string, ctx ...interface{}) {}
+func (l uncomparableLogger) Crit(msg string, ctx ...interface{})  {}
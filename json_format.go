@@ -0,0 +1,80 @@
+package rslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+)
+
+// JSONFmtConfig configures JSONFormat.
+type JSONFmtConfig struct {
+	// Pretty indents the emitted JSON for human readability. Defaults to
+	// compact single-line JSON when false, which is what most log
+	// collectors (ELK, Loki, Datadog) expect.
+	Pretty bool
+}
+
+// NewJSONFileHandler creates a file based logger that writes one JSON
+// object per record, suitable for ingestion by ELK/Loki/Datadog.
+func NewJSONFileHandler(path string) (log15.Handler, error) {
+	h, err := log15.FileHandler(path, JSONFormat(JSONFmtConfig{}))
+	if err != nil {
+		// Don't try to use log as that could panic
+		return nil, fmt.Errorf("failed to create log file %s: %s", path, err)
+	}
+	return h, nil
+}
+
+// JSONFormat returns a log15 formatter that emits one JSON object per
+// record with "ts" (RFC3339Nano), "level", "msg" and a "ctx" object built
+// from the record's context pairs. This mirrors the "empty tag" special
+// case ConfigurableFormatter gives legacy callers by promoting it to a
+// top-level "tag" field instead of folding it into ctx.
+func JSONFormat(opts JSONFmtConfig) log15.Format {
+	return log15.FormatFunc(func(r *log15.Record) []byte {
+		obj := map[string]interface{}{
+			"ts":    r.Time.Format("2006-01-02T15:04:05.999999999Z07:00"),
+			"level": strings.ToLower(r.Lvl.String()),
+			"msg":   r.Msg,
+		}
+
+		ctx := r.Ctx
+		ctxOffset := 0
+		if len(ctx) > 0 {
+			if k, ok := ctx[0].(string); ok && len(k) == 0 {
+				ctxOffset = 2
+				if v, ok := ctx[1].(string); ok {
+					obj["tag"] = v
+				} else {
+					obj["tag"] = "LOG_ERR"
+				}
+			}
+		}
+
+		if len(ctx) > ctxOffset {
+			c := make(map[string]interface{}, (len(ctx)-ctxOffset)/2)
+			for i := ctxOffset; i+1 < len(ctx); i += 2 {
+				k, ok := ctx[i].(string)
+				if !ok {
+					k = "LOG_ERR"
+				}
+				c[k] = formatShared(ctx[i+1])
+			}
+			obj["ctx"] = c
+		}
+
+		var b []byte
+		var err error
+		if opts.Pretty {
+			b, err = json.MarshalIndent(obj, "", "  ")
+		} else {
+			b, err = json.Marshal(obj)
+		}
+		if err != nil {
+			return []byte(fmt.Sprintf("{\"level\":\"error\",\"msg\":\"failed to marshal log record: %s\"}\n", err))
+		}
+		return append(b, '\n')
+	})
+}
@@ -0,0 +1,72 @@
+package rslog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/inconshreveable/log15"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONFormat", func() {
+	It("emits ts/level/msg plus a ctx object built from the record's context", func() {
+		f := JSONFormat(JSONFmtConfig{})
+		r := &log15.Record{Lvl: log15.LvlWarn, Msg: "disk low", Ctx: []interface{}{"free", "1GB"}}
+
+		var obj map[string]interface{}
+		Ω(json.Unmarshal(f.Format(r), &obj)).Should(Succeed())
+
+		Ω(obj["level"]).Should(Equal("warn"))
+		Ω(obj["msg"]).Should(Equal("disk low"))
+		Ω(obj["ctx"]).Should(Equal(map[string]interface{}{"free": "1GB"}))
+	})
+
+	It("promotes the legacy empty-key tag pair to a top-level tag field", func() {
+		f := JSONFormat(JSONFmtConfig{})
+		r := &log15.Record{Lvl: log15.LvlInfo, Msg: "hi", Ctx: []interface{}{"", "mytag", "k", "v"}}
+
+		var obj map[string]interface{}
+		Ω(json.Unmarshal(f.Format(r), &obj)).Should(Succeed())
+
+		Ω(obj["tag"]).Should(Equal("mytag"))
+		Ω(obj["ctx"]).Should(Equal(map[string]interface{}{"k": "v"}))
+	})
+
+	It("falls back to LOG_ERR for non-string context keys", func() {
+		f := JSONFormat(JSONFmtConfig{})
+		r := &log15.Record{Lvl: log15.LvlInfo, Msg: "hi", Ctx: []interface{}{42, "v"}}
+
+		var obj map[string]interface{}
+		Ω(json.Unmarshal(f.Format(r), &obj)).Should(Succeed())
+
+		Ω(obj["ctx"]).Should(Equal(map[string]interface{}{"LOG_ERR": "v"}))
+	})
+
+	It("indents the output when Pretty is set", func() {
+		f := JSONFormat(JSONFmtConfig{Pretty: true})
+		r := &log15.Record{Lvl: log15.LvlInfo, Msg: "hi"}
+
+		Ω(string(f.Format(r))).Should(ContainSubstring("\n  "))
+	})
+})
+
+var _ = Describe("NewJSONFileHandler", func() {
+	It("creates a usable log15 handler that writes JSON lines to path", func() {
+		dir, err := ioutil.TempDir("", "rslog-json")
+		Ω(err).ShouldNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := dir + "/app.log"
+
+		h, err := NewJSONFileHandler(path)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(h.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: "hi"})).Should(Succeed())
+
+		content, err := ioutil.ReadFile(path)
+		Ω(err).ShouldNot(HaveOccurred())
+		var obj map[string]interface{}
+		Ω(json.Unmarshal(content, &obj)).Should(Succeed())
+		Ω(obj["msg"]).Should(Equal("hi"))
+	})
+})
@@ -0,0 +1,144 @@
+package rslog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/inconshreveable/log15"
+)
+
+// LevelFilterHandler wraps h so that only records at lvl min or more severe
+// (i.e. r.Lvl <= min) reach it. Everything else is dropped.
+func LevelFilterHandler(min log15.Lvl, h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		if r.Lvl > min {
+			return nil
+		}
+		return h.Log(r)
+	})
+}
+
+// DynamicLeveler holds a per-package minimum log level that can be read and
+// changed at runtime, e.g. from LevelAdminHandler. The package a record
+// belongs to is taken from its "pkg" or "module" context value.
+type DynamicLeveler struct {
+	levels sync.Map // string (pkg) -> log15.Lvl
+	def    int32    // log15.Lvl, accessed atomically
+}
+
+// NewDynamicLeveler creates a DynamicLeveler whose default level (used for
+// packages with no override) is def.
+func NewDynamicLeveler(def log15.Lvl) *DynamicLeveler {
+	return &DynamicLeveler{def: int32(def)}
+}
+
+// SetLevel overrides the minimum level logged for pkg.
+func (d *DynamicLeveler) SetLevel(pkg string, lvl log15.Lvl) {
+	d.levels.Store(pkg, lvl)
+}
+
+// SetDefaultLevel changes the minimum level used for packages with no
+// specific override.
+func (d *DynamicLeveler) SetDefaultLevel(lvl log15.Lvl) {
+	atomic.StoreInt32(&d.def, int32(lvl))
+}
+
+// Level returns the level currently in effect for pkg.
+func (d *DynamicLeveler) Level(pkg string) log15.Lvl {
+	if v, ok := d.levels.Load(pkg); ok {
+		return v.(log15.Lvl)
+	}
+	return log15.Lvl(atomic.LoadInt32(&d.def))
+}
+
+// Handler wraps h, dropping any record whose package (per pkgFromCtx) is
+// logging below its currently configured level.
+func (d *DynamicLeveler) Handler(h log15.Handler) log15.Handler {
+	return log15.FuncHandler(func(r *log15.Record) error {
+		if r.Lvl > d.Level(pkgFromCtx(r.Ctx)) {
+			return nil
+		}
+		return h.Log(r)
+	})
+}
+
+// LevelAdminHandler returns an http.Handler that serves GET/PUT requests on
+// /log/level/{pkg} to inspect or change the level d applies to pkg. PUT
+// bodies are a level name as accepted by log15.LvlFromString (e.g. "debug").
+// {pkg} may be empty, in which case the request targets the default level.
+func (d *DynamicLeveler) LevelAdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pkg := strings.TrimPrefix(req.URL.Path, "/log/level/")
+
+		switch req.Method {
+		case http.MethodGet:
+			fmt.Fprintln(w, d.Level(pkg).String())
+
+		case http.MethodPut:
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			lvl, err := log15.LvlFromString(strings.TrimSpace(string(body)))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if pkg == "" {
+				d.SetDefaultLevel(lvl)
+			} else {
+				d.SetLevel(pkg, lvl)
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// pkgFromCtx extracts the "pkg" or "module" value from a log15.Record's
+// context pairs, returning "" if neither is present.
+func pkgFromCtx(ctx []interface{}) string {
+	for i := 0; i+1 < len(ctx); i += 2 {
+		k, ok := ctx[i].(string)
+		if !ok {
+			continue
+		}
+		if k != "pkg" && k != "module" {
+			continue
+		}
+		if v, ok := ctx[i+1].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// defaultLeveler is the DynamicLeveler backing the package-level
+// SetLevel/SetDefaultLevel/LevelAdminHandler convenience functions below.
+var defaultLeveler = NewDynamicLeveler(log15.LvlInfo)
+
+// SetLevel overrides the minimum level logged for pkg on the package's
+// default DynamicLeveler.
+func SetLevel(pkg string, lvl log15.Lvl) {
+	defaultLeveler.SetLevel(pkg, lvl)
+}
+
+// SetDefaultLevel changes the minimum level used for packages with no
+// specific override on the package's default DynamicLeveler.
+func SetDefaultLevel(lvl log15.Lvl) {
+	defaultLeveler.SetDefaultLevel(lvl)
+}
+
+// LevelAdminHandler returns an http.Handler serving the package's default
+// DynamicLeveler; see (*DynamicLeveler).LevelAdminHandler.
+func LevelAdminHandler() http.Handler {
+	return defaultLeveler.LevelAdminHandler()
+}
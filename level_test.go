@@ -0,0 +1,138 @@
+package rslog
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordingHandler captures every record it is given.
+type recordingHandler struct {
+	records []*log15.Record
+}
+
+func (h *recordingHandler) Log(r *log15.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+var _ = Describe("LevelFilterHandler", func() {
+	It("drops records less severe than min", func() {
+		rec := &recordingHandler{}
+		h := LevelFilterHandler(log15.LvlWarn, rec)
+
+		Ω(h.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: "dropped"})).Should(Succeed())
+		Ω(h.Log(&log15.Record{Lvl: log15.LvlWarn, Msg: "kept"})).Should(Succeed())
+		Ω(h.Log(&log15.Record{Lvl: log15.LvlError, Msg: "kept too"})).Should(Succeed())
+
+		Ω(rec.records).Should(HaveLen(2))
+		Ω(rec.records[0].Msg).Should(Equal("kept"))
+		Ω(rec.records[1].Msg).Should(Equal("kept too"))
+	})
+})
+
+var _ = Describe("DynamicLeveler", func() {
+	var d *DynamicLeveler
+
+	BeforeEach(func() {
+		d = NewDynamicLeveler(log15.LvlInfo)
+	})
+
+	Describe("Level", func() {
+		It("returns the default level for an unconfigured package", func() {
+			Ω(d.Level("unknown")).Should(Equal(log15.LvlInfo))
+		})
+
+		It("returns the per-package override once set", func() {
+			d.SetLevel("db", log15.LvlDebug)
+			Ω(d.Level("db")).Should(Equal(log15.LvlDebug))
+			Ω(d.Level("other")).Should(Equal(log15.LvlInfo))
+		})
+
+		It("reflects SetDefaultLevel for packages with no override", func() {
+			d.SetDefaultLevel(log15.LvlError)
+			Ω(d.Level("anything")).Should(Equal(log15.LvlError))
+		})
+	})
+
+	Describe("Handler", func() {
+		It("filters records per package using the pkg/module context key", func() {
+			d.SetDefaultLevel(log15.LvlWarn)
+			d.SetLevel("db", log15.LvlDebug)
+			rec := &recordingHandler{}
+			h := d.Handler(rec)
+
+			Ω(h.Log(&log15.Record{Lvl: log15.LvlDebug, Msg: "db debug", Ctx: []interface{}{"pkg", "db"}})).Should(Succeed())
+			Ω(h.Log(&log15.Record{Lvl: log15.LvlDebug, Msg: "other debug", Ctx: []interface{}{"pkg", "other"}})).Should(Succeed())
+			Ω(h.Log(&log15.Record{Lvl: log15.LvlWarn, Msg: "no pkg"})).Should(Succeed())
+
+			Ω(rec.records).Should(HaveLen(2))
+			Ω(rec.records[0].Msg).Should(Equal("db debug"))
+			Ω(rec.records[1].Msg).Should(Equal("no pkg"))
+		})
+	})
+
+	Describe("LevelAdminHandler", func() {
+		var srv *httptest.Server
+
+		BeforeEach(func() {
+			srv = httptest.NewServer(d.LevelAdminHandler())
+		})
+
+		AfterEach(func() {
+			srv.Close()
+		})
+
+		It("reports the default level on GET with no pkg", func() {
+			resp, err := http.Get(srv.URL + "/log/level/")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer resp.Body.Close()
+			Ω(resp.StatusCode).Should(Equal(http.StatusOK))
+
+			body, err := ioutil.ReadAll(resp.Body)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(body)).Should(Equal(log15.LvlInfo.String() + "\n"))
+		})
+
+		It("changes a package's level on PUT and reflects it on GET", func() {
+			req, err := http.NewRequest(http.MethodPut, srv.URL+"/log/level/db", strings.NewReader("debug"))
+			Ω(err).ShouldNot(HaveOccurred())
+			resp, err := http.DefaultClient.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp.Body.Close()
+			Ω(resp.StatusCode).Should(Equal(http.StatusNoContent))
+
+			Ω(d.Level("db")).Should(Equal(log15.LvlDebug))
+
+			getResp, err := http.Get(srv.URL + "/log/level/db")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer getResp.Body.Close()
+			body, err := ioutil.ReadAll(getResp.Body)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(body)).Should(Equal(log15.LvlDebug.String() + "\n"))
+		})
+
+		It("rejects an unknown level name on PUT", func() {
+			req, err := http.NewRequest(http.MethodPut, srv.URL+"/log/level/db", strings.NewReader("not-a-level"))
+			Ω(err).ShouldNot(HaveOccurred())
+			resp, err := http.DefaultClient.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp.Body.Close()
+			Ω(resp.StatusCode).Should(Equal(http.StatusBadRequest))
+		})
+
+		It("rejects methods other than GET/PUT", func() {
+			req, err := http.NewRequest(http.MethodDelete, srv.URL+"/log/level/db", nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp, err := http.DefaultClient.Do(req)
+			Ω(err).ShouldNot(HaveOccurred())
+			resp.Body.Close()
+			Ω(resp.StatusCode).Should(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
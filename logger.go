@@ -36,46 +36,67 @@ func NewSyslogHandler(tag string) (log15.Handler, error) {
 }
 
 // NewTCPSyslogHandler creates a new syslog based handler that talks to
-// syslog on the provided address using TCP protocol.
+// syslog on the provided address using TCP protocol. The connection is
+// automatically redialed if it drops; see NewTCPSyslogHandlerWithOptions to
+// control the amount of buffering used while it is down.
 func NewTCPSyslogHandler(addr string, tag string) (log15.Handler, error) {
-	sysWr, err := SyslogNewTCP(addr, syslog.LOG_NOTICE|syslog.LOG_LOCAL0, tag)
+	return NewTCPSyslogHandlerWithOptions(addr, tag, SyslogOptions{})
+}
+
+// NewTCPSyslogHandlerWithOptions is like NewTCPSyslogHandler but allows
+// tuning the reconnect buffering behavior via opts.
+func NewTCPSyslogHandlerWithOptions(addr string, tag string, opts SyslogOptions) (log15.Handler, error) {
+	target := newTCPSyslogTarget(addr, syslog.LOG_NOTICE|syslog.LOG_LOCAL0, tag)
+	conn, err := target.dial()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to syslog: %s", err)
 	}
-	return newSyslogHandler(sysWr), nil
+	return newReconnectingSyslogHandler(target, conn, opts), nil
 }
 
 // NewUDPSyslogHandler creates a new syslog based handler that talks to
-// syslog on the provided address using UDP protocol.
+// syslog on the provided address using UDP protocol. The connection is
+// automatically redialed if it drops; see NewUDPSyslogHandlerWithOptions to
+// control the amount of buffering used while it is down.
 func NewUDPSyslogHandler(addr string, tag string) (log15.Handler, error) {
-	sysWr, err := SyslogNewUDP(addr, syslog.LOG_NOTICE|syslog.LOG_LOCAL0, tag)
+	return NewUDPSyslogHandlerWithOptions(addr, tag, SyslogOptions{})
+}
+
+// NewUDPSyslogHandlerWithOptions is like NewUDPSyslogHandler but allows
+// tuning the reconnect buffering behavior via opts.
+func NewUDPSyslogHandlerWithOptions(addr string, tag string, opts SyslogOptions) (log15.Handler, error) {
+	target := newUDPSyslogTarget(addr, syslog.LOG_NOTICE|syslog.LOG_LOCAL0, tag)
+	conn, err := target.dial()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to syslog: %s", err)
 	}
-	return newSyslogHandler(sysWr), nil
+	return newReconnectingSyslogHandler(target, conn, opts), nil
 }
 
 func newSyslogHandler(sysWr *syslog.Writer) log15.Handler {
 	return log15.FuncHandler(func(r *log15.Record) error {
-		var syslogFn = sysWr.Info
-		switch r.Lvl {
-		case log15.LvlCrit:
-			syslogFn = sysWr.Crit
-		case log15.LvlError:
-			syslogFn = sysWr.Err
-		case log15.LvlWarn:
-			syslogFn = sysWr.Warning
-		case log15.LvlInfo:
-			syslogFn = sysWr.Info
-		case log15.LvlDebug:
-			syslogFn = sysWr.Debug
-		}
 		fmtr := SimpleFormat(false)
 		s := strings.TrimSpace(string(fmtr.Format(r)))
-		return syslogFn(s)
+		return syslogWriteFunc(sysWr, r.Lvl)(s)
 	})
 }
 
+// syslogWriteFunc returns the *syslog.Writer method appropriate for lvl.
+func syslogWriteFunc(sysWr *syslog.Writer, lvl log15.Lvl) func(string) error {
+	switch lvl {
+	case log15.LvlCrit:
+		return sysWr.Crit
+	case log15.LvlError:
+		return sysWr.Err
+	case log15.LvlWarn:
+		return sysWr.Warning
+	case log15.LvlDebug:
+		return sysWr.Debug
+	default:
+		return sysWr.Info
+	}
+}
+
 // SimpleFormat returns a log15 formatter that uses a logfmt like output.
 // The timestamps switch can be used to toggle prefixing each entry with the current time.
 // (see https://brandur.org/logfmt)
@@ -110,6 +131,18 @@ type FmtConfig struct {
 	Level            bool
 	MsgCtxSeparator  string
 	MsgJustification int
+
+	// AppName, MsgID, SDID, Hostname and MessageFormat are used by
+	// RFC5424Format only; they are ignored by
+	// ConfigurableFormatter/SimpleFormat/TerseFormat. Hostname defaults to
+	// the local hostname when left empty. MessageFormat, when set, renders
+	// the MSG part of the record instead of the plain r.Msg string - e.g.
+	// JSONFormat to ship JSON payloads over RFC5424 syslog.
+	AppName       string
+	MsgID         string
+	SDID          string
+	Hostname      string
+	MessageFormat log15.Format
 }
 
 // ConfigurableFormatter allows to set timestamp, logLevel, message to context
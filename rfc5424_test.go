@@ -0,0 +1,102 @@
+package rslog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeNetConn is a minimal net.Conn that records everything written to it.
+type fakeNetConn struct {
+	mu     sync.Mutex
+	writes [][]byte
+}
+
+func (c *fakeNetConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cp := make([]byte, len(b))
+	copy(cp, b)
+	c.writes = append(c.writes, cp)
+	return len(b), nil
+}
+
+func (c *fakeNetConn) Writes() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([][]byte, len(c.writes))
+	copy(out, c.writes)
+	return out
+}
+
+func (c *fakeNetConn) Read(b []byte) (int, error)         { return 0, fmt.Errorf("not implemented") }
+func (c *fakeNetConn) Close() error                       { return nil }
+func (c *fakeNetConn) LocalAddr() net.Addr                { return nil }
+func (c *fakeNetConn) RemoteAddr() net.Addr               { return nil }
+func (c *fakeNetConn) SetDeadline(t time.Time) error      { return nil }
+func (c *fakeNetConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *fakeNetConn) SetWriteDeadline(t time.Time) error { return nil }
+
+var _ = Describe("RFC5424Format", func() {
+	It("renders PRI, the structured-data element and the message", func() {
+		f := RFC5424Format(FmtConfig{AppName: "myapp", MsgID: "ID1", SDID: "myapp@1"})
+		r := &log15.Record{Lvl: log15.LvlWarn, Msg: "disk low", Ctx: []interface{}{"free", "1GB"}}
+
+		s := string(f.Format(r))
+
+		Ω(s).Should(HavePrefix(fmt.Sprintf("<%d>1 ", rfc5424Priority(log15.LvlWarn))))
+		Ω(s).Should(ContainSubstring(" myapp "))
+		Ω(s).Should(ContainSubstring(" ID1 "))
+		Ω(s).Should(ContainSubstring(`[myapp@1 free="1GB"]`))
+		Ω(s).Should(ContainSubstring("disk low"))
+	})
+
+	It("uses the NILVALUE for structured data when SDID is empty or there is no context", func() {
+		f := RFC5424Format(FmtConfig{AppName: "myapp", MsgID: "ID1"})
+		r := &log15.Record{Lvl: log15.LvlInfo, Msg: "hi"}
+
+		s := string(f.Format(r))
+
+		Ω(s).Should(ContainSubstring(" ID1 - "))
+	})
+
+	It("escapes ']', '\"' and '\\\\' inside SD-PARAM values", func() {
+		b := &bytes.Buffer{}
+		writeRFC5424StructuredData(b, "myapp@1", []interface{}{"msg", `a]b"c\d`})
+		Ω(b.String()).Should(Equal(`[myapp@1 msg="a\]b\"c\\d"]`))
+	})
+})
+
+var _ = Describe("rfc5424Priority", func() {
+	It("computes facility*8+severity using the local0 facility", func() {
+		Ω(rfc5424Priority(log15.LvlCrit)).Should(Equal(16*8 + 2))
+		Ω(rfc5424Priority(log15.LvlError)).Should(Equal(16*8 + 3))
+		Ω(rfc5424Priority(log15.LvlWarn)).Should(Equal(16*8 + 4))
+		Ω(rfc5424Priority(log15.LvlInfo)).Should(Equal(16*8 + 6))
+		Ω(rfc5424Priority(log15.LvlDebug)).Should(Equal(16*8 + 7))
+	})
+})
+
+var _ = Describe("NewRFC5424SyslogHandler", func() {
+	It("reuses the reconnecting syslog machinery", func() {
+		orig := NetDial
+		defer func() { NetDial = orig }()
+
+		conn := &fakeNetConn{}
+		NetDial = func(network, addr string) (net.Conn, error) { return conn, nil }
+
+		h, err := NewRFC5424SyslogHandler("127.0.0.1:1", "tcp", "myapp", "ID1", "myapp@1")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(h.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: "hi"})).Should(Succeed())
+		writes := conn.Writes()
+		Ω(writes).Should(HaveLen(1))
+		Ω(string(writes[0])).Should(ContainSubstring("hi"))
+	})
+})
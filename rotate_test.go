@@ -0,0 +1,173 @@
+package rslog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("rotatingWriter", func() {
+	var dir, path string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "rslog-rotate")
+		Ω(err).ShouldNot(HaveOccurred())
+		path = filepath.Join(dir, "app.log")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Write", func() {
+		It("does not rotate while under MaxSizeBytes", func() {
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 1024})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("hello\n"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			entries, err := ioutil.ReadDir(dir)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(entries).Should(HaveLen(1))
+		})
+
+		It("rotates to a numbered backup once MaxSizeBytes is exceeded", func() {
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 10})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("0123456789"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = w.Write([]byte("more"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			backup := path + ".1"
+			Ω(backup).Should(BeAnExistingFile())
+			content, err := ioutil.ReadFile(backup)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(Equal("0123456789"))
+
+			content, err = ioutil.ReadFile(path)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(content)).Should(Equal("more"))
+		})
+
+		It("does not clobber an existing numbered backup after a restart", func() {
+			Ω(ioutil.WriteFile(path+".1", []byte("from a previous process"), 0644)).Should(Succeed())
+
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 10})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("0123456789"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = w.Write([]byte("more"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			old, err := ioutil.ReadFile(path + ".1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(old)).Should(Equal("from a previous process"))
+
+			newBackup, err := ioutil.ReadFile(path + ".2")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(newBackup)).Should(Equal("0123456789"))
+		})
+
+		It("names backups with a timestamp when LocalTime is set", func() {
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 10, LocalTime: true})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("0123456789"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = w.Write([]byte("more"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			entries, err := ioutil.ReadDir(dir)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(entries).Should(HaveLen(2))
+			Ω(entries[1].Name()).Should(MatchRegexp(`^app\.log\.\d{8}T\d{6}$`))
+		})
+
+		It("gzip-compresses rotated backups when Compress is set", func() {
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 10, Compress: true})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("0123456789"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = w.Write([]byte("more"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() bool {
+				_, err := os.Stat(path + ".1.gz")
+				return err == nil
+			}, "2s", "10ms").Should(BeTrue())
+
+			Eventually(func() bool {
+				_, err := os.Stat(path + ".1")
+				return os.IsNotExist(err)
+			}, "2s", "10ms").Should(BeTrue())
+		})
+
+		It("prunes backups older than MaxAgeDays", func() {
+			// Pre-create a backup old enough to be pruned, and backdate its
+			// mtime so it predates the cutoff.
+			oldBackup := path + ".1"
+			Ω(ioutil.WriteFile(oldBackup, []byte("stale"), 0644)).Should(Succeed())
+			oldTime := time.Now().AddDate(0, 0, -30)
+			Ω(os.Chtimes(oldBackup, oldTime, oldTime)).Should(Succeed())
+
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 1, MaxAgeDays: 7})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("trigger rotation"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() bool {
+				_, err := os.Stat(oldBackup)
+				return os.IsNotExist(err)
+			}, "2s", "10ms").Should(BeTrue())
+
+			// The fresh backup just created by this rotation is within
+			// MaxAgeDays and must survive.
+			newBackup := path + ".2"
+			Ω(newBackup).Should(BeAnExistingFile())
+		})
+
+		It("prunes backups beyond MaxBackups", func() {
+			w, err := newRotatingWriter(path, RotateOptions{MaxSizeBytes: 1, MaxBackups: 1})
+			Ω(err).ShouldNot(HaveOccurred())
+			defer w.Close()
+
+			_, err = w.Write([]byte("a"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = w.Write([]byte("b"))
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = w.Write([]byte("c"))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Eventually(func() int {
+				entries, err := ioutil.ReadDir(dir)
+				Ω(err).ShouldNot(HaveOccurred())
+				return len(entries)
+			}, "2s", "10ms").Should(Equal(2)) // active file + 1 backup
+		})
+	})
+
+	Describe("NewRotatingFileHandler", func() {
+		It("creates a usable log15 handler", func() {
+			h, err := NewRotatingFileHandler(path, RotateOptions{MaxSizeBytes: 1024})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(h).ShouldNot(BeNil())
+		})
+	})
+})
@@ -0,0 +1,261 @@
+package rslog
+
+import (
+	"log/syslog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inconshreveable/log15"
+)
+
+// SyslogOptions configures the reconnect buffering behavior of the TCP/UDP
+// syslog handlers.
+type SyslogOptions struct {
+	// BufferSize is the number of log entries retained in memory while the
+	// syslog connection is down. Once full, the oldest entry is dropped to
+	// make room for the newest one. Defaults to defaultSyslogBufferSize when
+	// zero.
+	BufferSize int
+
+	// Format overrides the log15.Format used to render each record before it
+	// is handed to the underlying connection for the record's level.
+	// Defaults to SimpleFormat(false). Use JSONFormat to ship JSON payloads
+	// to a collector.
+	Format log15.Format
+}
+
+const (
+	defaultSyslogBufferSize = 1000
+	minReconnectBackoff     = 500 * time.Millisecond
+	maxReconnectBackoff     = 30 * time.Second
+)
+
+// NetDial is used to dial the raw connections behind NewRFC5424SyslogHandler.
+// Overridable for testing, same as SyslogNew/SyslogNewTCP/SyslogNewUDP in
+// logger.go.
+var NetDial = net.Dial
+
+// syslogConn is the minimal interface reconnectingWriter needs from
+// whatever transport it writes log lines through: a *syslog.Writer dialed
+// via SyslogNewTCP/SyslogNewUDP for the RFC3164 handlers, or a raw net.Conn
+// for NewRFC5424SyslogHandler, which builds the entire wire format
+// (including PRI and timestamp) itself.
+type syslogConn interface {
+	writeLevel(lvl log15.Lvl, s string) error
+	Close() error
+}
+
+// sysWriterConn adapts a *syslog.Writer to syslogConn, dispatching to the
+// method matching the record's level.
+type sysWriterConn struct{ wr *syslog.Writer }
+
+func (c sysWriterConn) writeLevel(lvl log15.Lvl, s string) error {
+	return syslogWriteFunc(c.wr, lvl)(s)
+}
+func (c sysWriterConn) Close() error { return c.wr.Close() }
+
+// rawConn adapts a net.Conn to syslogConn for handlers, such as
+// NewRFC5424SyslogHandler, that format the full message (including PRI and
+// timestamp) themselves and so ignore lvl. writeLevel terminates each
+// message with a newline.
+type rawConn struct{ conn net.Conn }
+
+func (c rawConn) writeLevel(_ log15.Lvl, s string) error {
+	_, err := c.conn.Write([]byte(s + "\n"))
+	return err
+}
+func (c rawConn) Close() error { return c.conn.Close() }
+
+// syslogTarget captures everything needed to (re)dial a syslog connection so
+// a reconnectingWriter can redial using the exact same parameters after the
+// connection is lost.
+type syslogTarget struct {
+	dial func() (syslogConn, error)
+}
+
+// newTCPSyslogTarget builds a syslogTarget that (re)dials an RFC3164
+// *syslog.Writer over TCP via SyslogNewTCP.
+func newTCPSyslogTarget(addr string, priority syslog.Priority, tag string) *syslogTarget {
+	return &syslogTarget{dial: func() (syslogConn, error) {
+		wr, err := SyslogNewTCP(addr, priority, tag)
+		if err != nil {
+			return nil, err
+		}
+		return sysWriterConn{wr}, nil
+	}}
+}
+
+// newUDPSyslogTarget builds a syslogTarget that (re)dials an RFC3164
+// *syslog.Writer over UDP via SyslogNewUDP.
+func newUDPSyslogTarget(addr string, priority syslog.Priority, tag string) *syslogTarget {
+	return &syslogTarget{dial: func() (syslogConn, error) {
+		wr, err := SyslogNewUDP(addr, priority, tag)
+		if err != nil {
+			return nil, err
+		}
+		return sysWriterConn{wr}, nil
+	}}
+}
+
+// newRawSyslogTarget builds a syslogTarget that (re)dials a plain net.Conn,
+// for handlers such as NewRFC5424SyslogHandler that format the full wire
+// message themselves.
+func newRawSyslogTarget(network, addr string) *syslogTarget {
+	return &syslogTarget{dial: func() (syslogConn, error) {
+		conn, err := NetDial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		return rawConn{conn}, nil
+	}}
+}
+
+// bufEntry is a single log line buffered while the connection is down,
+// along with the level it was logged at so it can be replayed through the
+// matching syslogConn method once reconnected.
+type bufEntry struct {
+	lvl log15.Lvl
+	msg string
+}
+
+// reconnectingWriter wraps a syslogConn and transparently redials the
+// target whenever a write fails. This mirrors the "deferred connection plus
+// single-attempt retry" model used by Go's own log/syslog (see CL 6782140):
+// a write error triggers exactly one redial and retry, and while the
+// connection is down entries pile up in a bounded ring buffer instead of
+// being lost outright. A background goroutine keeps retrying the dial with
+// capped exponential backoff and flushes the buffer once it succeeds.
+type reconnectingWriter struct {
+	target *syslogTarget
+
+	mu      sync.Mutex
+	conn    syslogConn
+	buf     []bufEntry
+	bufSize int
+	down    bool
+}
+
+func newReconnectingWriter(target *syslogTarget, conn syslogConn, opts SyslogOptions) *reconnectingWriter {
+	bufSize := opts.BufferSize
+	if bufSize <= 0 {
+		bufSize = defaultSyslogBufferSize
+	}
+	return &reconnectingWriter{target: target, conn: conn, bufSize: bufSize}
+}
+
+// write sends s to syslog at the given level, redialing once on failure. If
+// the connection is already known to be down the entry is appended to the
+// ring buffer instead.
+func (w *reconnectingWriter) write(lvl log15.Lvl, s string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.down {
+		w.buffer(lvl, s)
+		return nil
+	}
+
+	if err := w.conn.writeLevel(lvl, s); err == nil {
+		return nil
+	}
+
+	w.conn.Close()
+	conn, err := w.target.dial()
+	if err != nil {
+		w.buffer(lvl, s)
+		w.goDown()
+		return nil
+	}
+	w.conn = conn
+	if err := w.conn.writeLevel(lvl, s); err != nil {
+		w.buffer(lvl, s)
+		w.goDown()
+		return nil
+	}
+	return nil
+}
+
+// buffer appends s to the ring buffer, dropping the oldest entry once full.
+// Caller must hold w.mu.
+func (w *reconnectingWriter) buffer(lvl log15.Lvl, s string) {
+	if len(w.buf) >= w.bufSize {
+		w.buf = w.buf[1:]
+	}
+	w.buf = append(w.buf, bufEntry{lvl: lvl, msg: s})
+}
+
+// goDown marks the writer as disconnected and starts the background
+// reconnect loop, unless one is already running. Caller must hold w.mu.
+func (w *reconnectingWriter) goDown() {
+	if w.down {
+		return
+	}
+	w.down = true
+	go w.reconnectLoop()
+}
+
+// isDown reports whether the writer currently believes its connection is
+// down. Safe to call concurrently with write/reconnectLoop.
+func (w *reconnectingWriter) isDown() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.down
+}
+
+// bufferedMsgs returns the messages currently sitting in the ring buffer, in
+// order. Safe to call concurrently with write/reconnectLoop.
+func (w *reconnectingWriter) bufferedMsgs() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msgs := make([]string, len(w.buf))
+	for i, e := range w.buf {
+		msgs[i] = e.msg
+	}
+	return msgs
+}
+
+// reconnectLoop retries dialing the target with capped exponential backoff
+// until it succeeds, then flushes any buffered entries in order.
+func (w *reconnectingWriter) reconnectLoop() {
+	backoff := minReconnectBackoff
+	for {
+		time.Sleep(backoff)
+
+		conn, err := w.target.dial()
+		if err != nil {
+			backoff *= 2
+			if backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+			continue
+		}
+
+		w.mu.Lock()
+		w.conn = conn
+		w.down = false
+		buffered := w.buf
+		w.buf = nil
+		w.mu.Unlock()
+
+		for _, e := range buffered {
+			conn.writeLevel(e.lvl, e.msg)
+		}
+		return
+	}
+}
+
+// newReconnectingSyslogHandler creates a log15.Handler that logs through a
+// reconnectingWriter for target, seeded with the already-dialed conn.
+func newReconnectingSyslogHandler(target *syslogTarget, conn syslogConn, opts SyslogOptions) log15.Handler {
+	rw := newReconnectingWriter(target, conn, opts)
+	fmtr := opts.Format
+	if fmtr == nil {
+		fmtr = SimpleFormat(false)
+	}
+	return log15.FuncHandler(func(r *log15.Record) error {
+		s := strings.TrimSpace(string(fmtr.Format(r)))
+		return rw.write(r.Lvl, s)
+	})
+}
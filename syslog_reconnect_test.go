@@ -0,0 +1,162 @@
+package rslog
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSyslogConn is an in-memory syslogConn used to drive reconnectingWriter
+// without a real network connection.
+type fakeSyslogConn struct {
+	mu       sync.Mutex
+	writes   []string
+	failNext bool
+	closed   bool
+}
+
+func (c *fakeSyslogConn) writeLevel(_ log15.Lvl, s string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failNext {
+		c.failNext = false
+		return fmt.Errorf("write failed")
+	}
+	c.writes = append(c.writes, s)
+	return nil
+}
+
+func (c *fakeSyslogConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *fakeSyslogConn) Writes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]string, len(c.writes))
+	copy(out, c.writes)
+	return out
+}
+
+var _ = Describe("reconnectingWriter", func() {
+	var target *syslogTarget
+
+	Describe("write", func() {
+		Context("when the connection is healthy", func() {
+			It("writes straight through without redialing", func() {
+				conn := &fakeSyslogConn{}
+				target = &syslogTarget{dial: func() (syslogConn, error) {
+					return nil, fmt.Errorf("should not be called")
+				}}
+				rw := newReconnectingWriter(target, conn, SyslogOptions{})
+
+				Ω(rw.write(log15.LvlInfo, "hello")).Should(Succeed())
+				Ω(conn.Writes()).Should(Equal([]string{"hello"}))
+				Ω(conn.closed).Should(BeFalse())
+			})
+		})
+
+		Context("when the write fails but the redial succeeds", func() {
+			It("closes the old connection and retries exactly once", func() {
+				oldConn := &fakeSyslogConn{failNext: true}
+				newConn := &fakeSyslogConn{}
+				target = &syslogTarget{dial: func() (syslogConn, error) {
+					return newConn, nil
+				}}
+				rw := newReconnectingWriter(target, oldConn, SyslogOptions{})
+
+				Ω(rw.write(log15.LvlInfo, "hello")).Should(Succeed())
+				Ω(oldConn.closed).Should(BeTrue())
+				Ω(newConn.Writes()).Should(Equal([]string{"hello"}))
+			})
+		})
+
+		Context("when the write fails and the redial also fails", func() {
+			It("buffers the entry instead of losing it", func() {
+				oldConn := &fakeSyslogConn{failNext: true}
+				target = &syslogTarget{dial: func() (syslogConn, error) {
+					return nil, fmt.Errorf("connection refused")
+				}}
+				rw := newReconnectingWriter(target, oldConn, SyslogOptions{})
+
+				Ω(rw.write(log15.LvlInfo, "hello")).Should(Succeed())
+				Ω(rw.isDown()).Should(BeTrue())
+				Ω(rw.bufferedMsgs()).Should(Equal([]string{"hello"}))
+
+				// Further writes pile up in the buffer too, without touching
+				// the (down) connection at all.
+				Ω(rw.write(log15.LvlInfo, "world")).Should(Succeed())
+				Ω(rw.bufferedMsgs()).Should(HaveLen(2))
+			})
+		})
+
+		Context("when the buffer is full", func() {
+			It("drops the oldest buffered entry to make room", func() {
+				oldConn := &fakeSyslogConn{failNext: true}
+				target = &syslogTarget{dial: func() (syslogConn, error) {
+					return nil, fmt.Errorf("connection refused")
+				}}
+				rw := newReconnectingWriter(target, oldConn, SyslogOptions{BufferSize: 2})
+
+				Ω(rw.write(log15.LvlInfo, "a")).Should(Succeed())
+				Ω(rw.write(log15.LvlInfo, "b")).Should(Succeed())
+				Ω(rw.write(log15.LvlInfo, "c")).Should(Succeed())
+
+				Ω(rw.bufferedMsgs()).Should(Equal([]string{"b", "c"}))
+			})
+		})
+	})
+
+	Describe("reconnectLoop", func() {
+		It("flushes buffered entries once the target becomes reachable again", func() {
+			oldConn := &fakeSyslogConn{failNext: true}
+			newConn := &fakeSyslogConn{}
+
+			var dialAttempts int32
+			var mu sync.Mutex
+			target = &syslogTarget{dial: func() (syslogConn, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				dialAttempts++
+				if dialAttempts < 2 {
+					return nil, fmt.Errorf("still down")
+				}
+				return newConn, nil
+			}}
+			rw := newReconnectingWriter(target, oldConn, SyslogOptions{})
+
+			// First write fails, redial fails too (dialAttempts == 1): goes
+			// down and buffers.
+			Ω(rw.write(log15.LvlInfo, "buffered")).Should(Succeed())
+			Ω(rw.isDown()).Should(BeTrue())
+
+			// reconnectLoop's next dial attempt (dialAttempts == 2) succeeds
+			// and should flush the buffer onto newConn.
+			Eventually(func() []string {
+				return newConn.Writes()
+			}, "3s", "10ms").Should(Equal([]string{"buffered"}))
+
+			Eventually(rw.isDown, "1s", "10ms").Should(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("newReconnectingSyslogHandler", func() {
+	It("renders records with opts.Format when set", func() {
+		conn := &fakeSyslogConn{}
+		target := &syslogTarget{dial: func() (syslogConn, error) {
+			return nil, fmt.Errorf("should not be called")
+		}}
+		h := newReconnectingSyslogHandler(target, conn, SyslogOptions{Format: JSONFormat(JSONFmtConfig{})})
+
+		Ω(h.Log(&log15.Record{Lvl: log15.LvlInfo, Msg: "hi"})).Should(Succeed())
+		Ω(conn.Writes()).Should(HaveLen(1))
+		Ω(conn.Writes()[0]).Should(ContainSubstring(`"msg":"hi"`))
+	})
+})